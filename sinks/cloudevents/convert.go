@@ -0,0 +1,160 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+const (
+	// DefaultSource is the CloudEvents "source" attribute used when no
+	// transport-specific override is configured.
+	DefaultSource = "acs.cs"
+	// DefaultType is the CloudEvents "type" attribute used when no
+	// transport-specific override is configured.
+	DefaultType = "cs:k8s:K8s-event-via-npd"
+	// DefaultBusName is the legacy EventBridge routing extension, kept as the
+	// default "aliyuneventbusname" extension for transports that understand it.
+	DefaultBusName = "default"
+)
+
+// EventContext carries the identifiers needed to build a stable CloudEvents
+// subject for events originating from a given cluster. It is populated once
+// by each sink from its own URI/config and passed into ToCloudEvent.
+type EventContext struct {
+	Region    string
+	AccountId string
+	ClusterId string
+}
+
+// InvolvedObjectEnricher optionally looks up the live involved object of an
+// event so its labels/annotations can be attached to the CloudEvent. Sinks
+// wire this up only when a Kubernetes client is available and the caller
+// opted in (e.g. via ?enrichInvolvedObject=true), since it costs an API call
+// per event.
+type InvolvedObjectEnricher interface {
+	Enrich(ref v1.ObjectReference) (labels map[string]string, annotations map[string]string, err error)
+}
+
+// InvolvedObject carries the labels/annotations of an event's involved
+// object, pre-fetched by the caller (e.g. because it's also needed for
+// routing decisions) so ToCloudEvent never has to fetch it itself. The zero
+// value means "no enrichment available".
+type InvolvedObject struct {
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// ToCloudEvent converts a Kubernetes event into a CloudEvents v1.0 event.
+// It is the single conversion used by every transport (EventBridge, HTTP,
+// MQTT, Kafka) so that they all emit identical events regardless of how they
+// are shipped downstream. involved may be the zero value when enrichment is
+// disabled or wasn't available for this event.
+func ToCloudEvent(ctx EventContext, event *v1.Event, involved InvolvedObject) (cloudevents.Event, error) {
+	ce := cloudevents.NewEvent()
+
+	dataBytes, err := json.Marshal(event)
+	if err != nil {
+		return ce, err
+	}
+
+	subject := CreateEventSubject(ctx, v1.ObjectReference{
+		APIVersion: event.APIVersion,
+		Kind:       event.Kind,
+		Name:       event.Name,
+		Namespace:  event.Namespace,
+	})
+
+	ce.SetID(uuid.New().String())
+	ce.SetSource(DefaultSource)
+	ce.SetType(DefaultType)
+	ce.SetSubject(subject)
+	ce.SetTime(eventTimestamp(event))
+	ce.SetExtension("aliyuneventbusname", DefaultBusName)
+
+	ce.SetExtension("kubernetescluster", ctx.ClusterId)
+	ce.SetExtension("kubernetesnamespace", event.InvolvedObject.Namespace)
+	ce.SetExtension("kubernetesobjectkind", event.InvolvedObject.Kind)
+	ce.SetExtension("kubernetesobjectname", event.InvolvedObject.Name)
+	ce.SetExtension("kubernetesobjectuid", string(event.InvolvedObject.UID))
+	ce.SetExtension("reason", event.Reason)
+	ce.SetExtension("eventtype", event.Type)
+	ce.SetExtension("reportingcomponent", reportingComponent(event))
+	if !event.FirstTimestamp.IsZero() {
+		ce.SetExtension("firsttimestamp", event.FirstTimestamp.Format(time.RFC3339))
+	}
+	if !event.LastTimestamp.IsZero() {
+		ce.SetExtension("lasttimestamp", event.LastTimestamp.Format(time.RFC3339))
+	}
+
+	// CloudEvents extension values must be bool/int32/string/[]byte/URI/
+	// Timestamp (see types.Validate) - a raw map is rejected by SetExtension,
+	// so labels/annotations are carried as JSON-encoded strings instead.
+	if len(involved.Labels) > 0 {
+		encoded, err := json.Marshal(involved.Labels)
+		if err != nil {
+			return ce, err
+		}
+		if err := ce.SetExtension("kubernetesobjectlabels", string(encoded)); err != nil {
+			return ce, err
+		}
+	}
+	if len(involved.Annotations) > 0 {
+		encoded, err := json.Marshal(involved.Annotations)
+		if err != nil {
+			return ce, err
+		}
+		if err := ce.SetExtension("kubernetesobjectannotations", string(encoded)); err != nil {
+			return ce, err
+		}
+	}
+
+	if err := ce.SetData("application/json", dataBytes); err != nil {
+		return ce, err
+	}
+
+	return ce, nil
+}
+
+// eventTimestamp prefers the event's own EventTime/LastTimestamp over
+// time.Now(), so downstream dedupe and ordering reflect when Kubernetes
+// actually observed the event rather than when kube-eventer shipped it.
+func eventTimestamp(event *v1.Event) time.Time {
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	return time.Now()
+}
+
+// reportingComponent falls back to the deprecated Source.Component field for
+// events emitted by pre-1.19 reporters that never set ReportingController.
+func reportingComponent(event *v1.Event) string {
+	if event.ReportingController != "" {
+		return event.ReportingController
+	}
+	return event.Source.Component
+}
+
+// CreateEventSubject builds a cloudevents subject of the form found in object
+// metadata selfLinks, like: acs:cs:${Region}:${Account}:${ClusterId}/${selfLink}
+func CreateEventSubject(ctx EventContext, o v1.ObjectReference) string {
+	gvr, _ := meta.UnsafeGuessKindToResource(o.GroupVersionKind())
+	versionNameHack := o.APIVersion
+
+	// Core API types don't have a separate package name and only have a version string (e.g. /apis/v1/namespaces/default/pods/myPod)
+	// To avoid weird looking strings like "v1/versionUnknown" we'll sniff for a "." in the version
+	if strings.Contains(versionNameHack, ".") && !strings.Contains(versionNameHack, "/") {
+		versionNameHack = versionNameHack + "/versionUnknown"
+	}
+	return fmt.Sprintf("acs:cs:%s:%s:%s/apis/%s/namespaces/%s/%s/%s", ctx.Region, ctx.AccountId,
+		ctx.ClusterId, versionNameHack, o.Namespace, gvr.Resource, o.Name)
+}
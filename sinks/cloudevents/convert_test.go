@@ -0,0 +1,109 @@
+package cloudevents
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEventTimestampPrefersEventTime(t *testing.T) {
+	eventTime := metav1.NewMicroTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	lastTimestamp := metav1.NewTime(time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC))
+
+	event := &v1.Event{EventTime: eventTime, LastTimestamp: lastTimestamp}
+
+	if got := eventTimestamp(event); !got.Equal(eventTime.Time) {
+		t.Fatalf("eventTimestamp() = %v, want EventTime %v", got, eventTime.Time)
+	}
+}
+
+func TestEventTimestampFallsBackToLastTimestamp(t *testing.T) {
+	lastTimestamp := metav1.NewTime(time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC))
+	event := &v1.Event{LastTimestamp: lastTimestamp}
+
+	if got := eventTimestamp(event); !got.Equal(lastTimestamp.Time) {
+		t.Fatalf("eventTimestamp() = %v, want LastTimestamp %v", got, lastTimestamp.Time)
+	}
+}
+
+func TestEventTimestampFallsBackToNow(t *testing.T) {
+	before := time.Now()
+	got := eventTimestamp(&v1.Event{})
+	after := time.Now()
+
+	if got.Before(before) || got.After(after) {
+		t.Fatalf("eventTimestamp() = %v, want a value between %v and %v", got, before, after)
+	}
+}
+
+func TestReportingComponentPrefersReportingController(t *testing.T) {
+	event := &v1.Event{ReportingController: "new-controller"}
+	event.Source.Component = "old-component"
+
+	if got := reportingComponent(event); got != "new-controller" {
+		t.Fatalf("reportingComponent() = %q, want %q", got, "new-controller")
+	}
+}
+
+func TestReportingComponentFallsBackToSourceComponent(t *testing.T) {
+	event := &v1.Event{}
+	event.Source.Component = "old-component"
+
+	if got := reportingComponent(event); got != "old-component" {
+		t.Fatalf("reportingComponent() = %q, want %q", got, "old-component")
+	}
+}
+
+func TestToCloudEventSetsCoreAttributes(t *testing.T) {
+	event := &v1.Event{
+		Reason: "Failed",
+		Type:   "Warning",
+	}
+	event.Name = "my-pod.1234"
+	event.Namespace = "default"
+	event.InvolvedObject = v1.ObjectReference{Kind: "Pod", Name: "my-pod", Namespace: "default"}
+
+	ctx := EventContext{Region: "cn-hangzhou", AccountId: "123", ClusterId: "c-abc"}
+
+	ce, err := ToCloudEvent(ctx, event, InvolvedObject{})
+	if err != nil {
+		t.Fatalf("ToCloudEvent: %v", err)
+	}
+
+	if ce.Source() != DefaultSource {
+		t.Errorf("Source() = %q, want %q", ce.Source(), DefaultSource)
+	}
+	if ce.Type() != DefaultType {
+		t.Errorf("Type() = %q, want %q", ce.Type(), DefaultType)
+	}
+	if got := ce.Extensions()["reason"]; got != "Failed" {
+		t.Errorf("reason extension = %v, want Failed", got)
+	}
+	if got := ce.Extensions()["kubernetesobjectkind"]; got != "Pod" {
+		t.Errorf("kubernetesobjectkind extension = %v, want Pod", got)
+	}
+}
+
+func TestCreateEventSubjectCoreAPIVersion(t *testing.T) {
+	ctx := EventContext{Region: "cn-hangzhou", AccountId: "123", ClusterId: "c-abc"}
+	ref := v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Name: "my-pod", Namespace: "default"}
+
+	got := CreateEventSubject(ctx, ref)
+	want := "acs:cs:cn-hangzhou:123:c-abc/apis/v1/namespaces/default/pods/my-pod"
+	if got != want {
+		t.Fatalf("CreateEventSubject() = %q, want %q", got, want)
+	}
+}
+
+func TestCreateEventSubjectGroupedAPIVersion(t *testing.T) {
+	ctx := EventContext{Region: "cn-hangzhou", AccountId: "123", ClusterId: "c-abc"}
+	ref := v1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "my-deploy", Namespace: "default"}
+
+	got := CreateEventSubject(ctx, ref)
+	want := "acs:cs:cn-hangzhou:123:c-abc/apis/apps/v1/namespaces/default/deployments/my-deploy"
+	if got != want {
+		t.Fatalf("CreateEventSubject() = %q, want %q", got, want)
+	}
+}
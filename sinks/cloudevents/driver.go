@@ -0,0 +1,95 @@
+package cloudevents
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/AliyunContainerService/kube-eventer/core"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/client"
+	"k8s.io/klog"
+)
+
+const cloudEventsSinkName = "CloudEventsSink"
+
+// cloudEventsSink ships Kubernetes events as CloudEvents to any receiver
+// supported by cloudevents/sdk-go v2 (HTTP, MQTT, Kafka), chosen by the
+// sink URI's "cloudevents+<transport>" scheme. This is the generic
+// counterpart to the EventBridge sink, for users who want to point
+// kube-eventer at a Knative Broker, Argo Events, Tekton Triggers, etc.
+type cloudEventsSink struct {
+	client   client.Client
+	closer   protocolCloser
+	context  EventContext
+	enricher InvolvedObjectEnricher
+}
+
+func NewCloudEventsSink(uri *url.URL) (core.EventSink, error) {
+	ceClient, closer, err := NewClient(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := uri.Query()
+	ctx := EventContext{}
+	if len(opts["clusterId"]) >= 1 {
+		ctx.ClusterId = opts["clusterId"][0]
+	}
+	if len(opts["region"]) >= 1 {
+		ctx.Region = opts["region"][0]
+	}
+	if len(opts["accountId"]) >= 1 {
+		ctx.AccountId = opts["accountId"][0]
+	}
+
+	sink := &cloudEventsSink{
+		client:  ceClient,
+		closer:  closer,
+		context: ctx,
+	}
+
+	if len(opts["enrichInvolvedObject"]) >= 1 && opts["enrichInvolvedObject"][0] == "true" {
+		sink.enricher, err = NewInvolvedObjectEnricher()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sink, nil
+}
+
+func (s *cloudEventsSink) Name() string {
+	return cloudEventsSinkName
+}
+
+func (s *cloudEventsSink) ExportEvents(batch *core.EventBatch) {
+	for _, event := range batch.Events {
+		var involved InvolvedObject
+		if s.enricher != nil {
+			labels, annotations, err := s.enricher.Enrich(event.InvolvedObject)
+			if err != nil {
+				klog.Errorf("failed to enrich involved object for event %v, because of %v", event, err)
+			} else {
+				involved = InvolvedObject{Labels: labels, Annotations: annotations}
+			}
+		}
+
+		ce, err := ToCloudEvent(s.context, event, involved)
+		if err != nil {
+			klog.Errorf("failed to convert event %v to cloudevents, because of %v", event, err)
+			continue
+		}
+		if result := s.client.Send(context.Background(), ce); !cloudevents.IsACK(result) {
+			klog.Errorf("failed to send cloudevent %v, because of %v", ce.ID(), result)
+		}
+	}
+}
+
+func (s *cloudEventsSink) Stop() {
+	if s.closer == nil {
+		return
+	}
+	if err := s.closer.Close(context.Background()); err != nil {
+		klog.Errorf("failed to close cloudevents transport, because of %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestToCloudEventEncodesInvolvedObjectLabelsAsJSON(t *testing.T) {
+	event := &v1.Event{}
+	involved := InvolvedObject{Labels: map[string]string{"app": "payments"}}
+
+	ce, err := ToCloudEvent(EventContext{}, event, involved)
+	if err != nil {
+		t.Fatalf("ToCloudEvent: %v", err)
+	}
+
+	raw, ok := ce.Extensions()["kubernetesobjectlabels"].(string)
+	if !ok {
+		t.Fatalf("kubernetesobjectlabels extension = %T, want string", ce.Extensions()["kubernetesobjectlabels"])
+	}
+
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		t.Fatalf("unmarshal kubernetesobjectlabels: %v", err)
+	}
+	if decoded["app"] != "payments" {
+		t.Fatalf("decoded labels = %v, want app=payments", decoded)
+	}
+}
+
+func TestToCloudEventEncodesInvolvedObjectAnnotationsAsJSON(t *testing.T) {
+	event := &v1.Event{}
+	involved := InvolvedObject{Annotations: map[string]string{"owner": "team-payments"}}
+
+	ce, err := ToCloudEvent(EventContext{}, event, involved)
+	if err != nil {
+		t.Fatalf("ToCloudEvent: %v", err)
+	}
+
+	raw, ok := ce.Extensions()["kubernetesobjectannotations"].(string)
+	if !ok {
+		t.Fatalf("kubernetesobjectannotations extension = %T, want string", ce.Extensions()["kubernetesobjectannotations"])
+	}
+
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		t.Fatalf("unmarshal kubernetesobjectannotations: %v", err)
+	}
+	if decoded["owner"] != "team-payments" {
+		t.Fatalf("decoded annotations = %v, want owner=team-payments", decoded)
+	}
+}
+
+func TestToCloudEventOmitsEmptyInvolvedObjectExtensions(t *testing.T) {
+	ce, err := ToCloudEvent(EventContext{}, &v1.Event{}, InvolvedObject{})
+	if err != nil {
+		t.Fatalf("ToCloudEvent: %v", err)
+	}
+
+	if _, ok := ce.Extensions()["kubernetesobjectlabels"]; ok {
+		t.Error("kubernetesobjectlabels should be omitted when no labels are present")
+	}
+	if _, ok := ce.Extensions()["kubernetesobjectannotations"]; ok {
+		t.Error("kubernetesobjectannotations should be omitted when no annotations are present")
+	}
+}
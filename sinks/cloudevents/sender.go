@@ -0,0 +1,171 @@
+package cloudevents
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2"
+	"github.com/cloudevents/sdk-go/protocol/mqtt_paho/v2"
+	"github.com/Shopify/sarama"
+	"github.com/eclipse/paho.golang/paho"
+)
+
+// scheme prefix every cloudevents sink URI must use, e.g.
+// cloudevents+http://..., cloudevents+mqtt://..., cloudevents+kafka_sarama://...
+const schemePrefix = "cloudevents+"
+
+// protocolCloser is implemented by the sdk-go protocols that hold a live
+// broker connection (mqtt_paho.Protocol, kafka_sarama.Sender) and must be
+// torn down on sink Stop().
+type protocolCloser interface {
+	Close(ctx context.Context) error
+}
+
+// NewClient builds a cloudevents.Client whose underlying protocol.Sender is
+// chosen by the "cloudevents+<transport>" scheme of uri. This is the single
+// place that knows how to turn a sink URI into a wire protocol, so adding a
+// new transport only requires a new case here. The returned protocolCloser
+// is non-nil whenever the transport owns a connection that must be closed;
+// callers should close it from Stop().
+func NewClient(uri *url.URL) (client.Client, protocolCloser, error) {
+	transport := strings.TrimPrefix(uri.Scheme, schemePrefix)
+
+	var sender interface{}
+	var err error
+
+	switch transport {
+	case "http":
+		sender, err = newHTTPProtocol(uri)
+	case "mqtt":
+		sender, err = newMQTTProtocol(uri)
+	case "kafka_sarama":
+		sender, err = newKafkaSaramaProtocol(uri)
+	default:
+		return nil, nil, fmt.Errorf("unsupported cloudevents transport %q, want one of: http, mqtt, kafka_sarama", transport)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := cloudevents.NewClient(sender)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closer, _ := sender.(protocolCloser)
+	return c, closer, nil
+}
+
+func newHTTPProtocol(uri *url.URL) (*cehttp.Protocol, error) {
+	target := *uri
+	target.Scheme = "http"
+	if opts := uri.Query(); len(opts["tls"]) >= 1 && opts["tls"][0] == "true" {
+		target.Scheme = "https"
+	}
+	target.RawQuery = ""
+
+	return cehttp.New(cehttp.WithTarget(target.String()))
+}
+
+func newMQTTProtocol(uri *url.URL) (*mqtt_paho.Protocol, error) {
+	opts := uri.Query()
+	topic := strings.TrimPrefix(uri.Path, "/")
+	if topic == "" {
+		return nil, fmt.Errorf("cloudevents+mqtt sink requires a topic in the URI path")
+	}
+
+	conn, err := newMQTTConnection(uri.Host, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return mqtt_paho.New(conn, topic)
+}
+
+func newKafkaSaramaProtocol(uri *url.URL) (*kafka_sarama.Sender, error) {
+	opts := uri.Query()
+	topic := strings.TrimPrefix(uri.Path, "/")
+	if topic == "" {
+		return nil, fmt.Errorf("cloudevents+kafka_sarama sink requires a topic in the URI path")
+	}
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Version = sarama.V2_0_0_0
+	saramaConfig.Producer.Return.Successes = true
+
+	return kafka_sarama.NewSender(strings.Split(uri.Host, ","), saramaConfig, topic)
+}
+
+// newMQTTConnection dials broker (host[:port], defaulting to 1883/8883),
+// applies ?clientId=/?username=/?password=/?tls= from opts, and performs the
+// MQTT CONNECT handshake before handing the client back for use as a
+// cloudevents sender.
+func newMQTTConnection(broker string, opts url.Values) (*paho.Client, error) {
+	addr := broker
+	useTLS := len(opts["tls"]) >= 1 && opts["tls"][0] == "true"
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		if useTLS {
+			addr = net.JoinHostPort(addr, "8883")
+		} else {
+			addr = net.JoinHostPort(addr, "1883")
+		}
+	}
+
+	var conn net.Conn
+	var err error
+	if useTLS {
+		conn, err = tls.Dial("tcp", addr, &tls.Config{})
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial mqtt broker %s: %w", addr, err)
+	}
+
+	c := paho.NewClient(paho.ClientConfig{Conn: conn})
+
+	connect := &paho.Connect{
+		KeepAlive:  30,
+		ClientID:   firstOptOr(opts, "clientId", "kube-eventer"),
+		CleanStart: true,
+	}
+	if v, ok := firstOpt(opts, "username"); ok {
+		connect.Username = v
+		connect.UsernameFlag = true
+	}
+	if v, ok := firstOpt(opts, "password"); ok {
+		connect.Password = []byte(v)
+		connect.PasswordFlag = true
+	}
+
+	ack, err := c.Connect(context.Background(), connect)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mqtt broker %s: %w", addr, err)
+	}
+	if ack.ReasonCode != 0 {
+		return nil, fmt.Errorf("mqtt broker %s rejected connect, reason code %d", addr, ack.ReasonCode)
+	}
+
+	return c, nil
+}
+
+func firstOptOr(opts url.Values, key, fallback string) string {
+	if v, ok := firstOpt(opts, key); ok {
+		return v
+	}
+	return fallback
+}
+
+func firstOpt(opts url.Values, key string) (string, bool) {
+	if v, ok := opts[key]; ok && len(v) >= 1 {
+		return v[0], true
+	}
+	return "", false
+}
@@ -0,0 +1,77 @@
+package cloudevents
+
+import (
+	"net/url"
+	"testing"
+)
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse(%q): %v", raw, err)
+	}
+	return u
+}
+
+func TestNewClientHTTP(t *testing.T) {
+	c, closer, err := NewClient(mustParseURL(t, "cloudevents+http://example.com/path"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if c == nil {
+		t.Fatal("NewClient returned a nil client for the http transport")
+	}
+	// The HTTP protocol doesn't hold a connection worth closing.
+	if closer != nil {
+		t.Fatalf("NewClient returned a non-nil closer for http, want nil")
+	}
+}
+
+func TestNewClientHTTPRespectsTLSOption(t *testing.T) {
+	// Covers the scheme-dispatch path that builds the target URL: this
+	// must not error just because ?tls=true switches http -> https.
+	if _, _, err := NewClient(mustParseURL(t, "cloudevents+http://example.com/path?tls=true")); err != nil {
+		t.Fatalf("NewClient with tls=true: %v", err)
+	}
+}
+
+func TestNewClientMQTTRequiresTopic(t *testing.T) {
+	if _, _, err := NewClient(mustParseURL(t, "cloudevents+mqtt://broker.example.com")); err == nil {
+		t.Fatal("NewClient should reject an mqtt sink URI with no topic in the path")
+	}
+}
+
+func TestNewClientKafkaRequiresTopic(t *testing.T) {
+	if _, _, err := NewClient(mustParseURL(t, "cloudevents+kafka_sarama://broker.example.com")); err == nil {
+		t.Fatal("NewClient should reject a kafka_sarama sink URI with no topic in the path")
+	}
+}
+
+func TestNewClientUnsupportedTransport(t *testing.T) {
+	if _, _, err := NewClient(mustParseURL(t, "cloudevents+amqp://broker.example.com/topic")); err == nil {
+		t.Fatal("NewClient should reject an unsupported transport scheme")
+	}
+}
+
+func TestFirstOpt(t *testing.T) {
+	opts := url.Values{"clientId": {"edge-1"}}
+
+	if v, ok := firstOpt(opts, "clientId"); !ok || v != "edge-1" {
+		t.Fatalf("firstOpt(clientId) = (%q, %v), want (edge-1, true)", v, ok)
+	}
+	if _, ok := firstOpt(opts, "missing"); ok {
+		t.Fatal("firstOpt should report false for a key that isn't set")
+	}
+}
+
+func TestFirstOptOr(t *testing.T) {
+	opts := url.Values{"clientId": {"edge-1"}}
+
+	if v := firstOptOr(opts, "clientId", "fallback"); v != "edge-1" {
+		t.Fatalf("firstOptOr(clientId) = %q, want edge-1", v)
+	}
+	if v := firstOptOr(opts, "missing", "fallback"); v != "fallback" {
+		t.Fatalf("firstOptOr(missing) = %q, want fallback", v)
+	}
+}
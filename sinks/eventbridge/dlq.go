@@ -0,0 +1,312 @@
+package eventbridge
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alibabacloud-go/eventbridge-sdk/eventbridge"
+	"github.com/google/uuid"
+	"k8s.io/klog"
+)
+
+const (
+	defaultDLQMaxBytes    = 64 * 1024 * 1024 // 64MiB
+	defaultDLQMaxAttempts = 10
+	dlqDrainInterval      = 30 * time.Second
+	dlqPoisonSubdir       = "poison"
+
+	dlqBaseBackoff = 10 * time.Second
+	dlqMaxBackoff  = 10 * time.Minute
+)
+
+// dlqEntry is the on-disk representation of one failed batch, including
+// enough bookkeeping to drive exponential backoff and eventual poisoning.
+type dlqEntry struct {
+	Attempts    int                       `json:"attempts"`
+	NextRetryAt time.Time                 `json:"nextRetryAt"`
+	Events      []*eventbridge.CloudEvent `json:"events"`
+}
+
+// diskDLQ is a bounded, disk-backed ring buffer of CloudEvent batches that
+// failed to reach EventBridge even after the SDK exhausted its own
+// autoretry. A background goroutine periodically re-drains it through put,
+// backing off exponentially per entry, until either the batch is delivered
+// or it exceeds maxAttempts and is moved into a poison subdirectory.
+type diskDLQ struct {
+	dir         string
+	maxBytes    int64
+	maxAttempts int
+	put         putEventsImpl
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// newDiskDLQ creates (if necessary) the dlq directory and its poison
+// subdirectory rooted at path.
+func newDiskDLQ(path string, maxBytes int64, maxAttempts int, put putEventsImpl) (*diskDLQ, error) {
+	if err := os.MkdirAll(filepath.Join(path, dlqPoisonSubdir), 0755); err != nil {
+		return nil, err
+	}
+
+	return &diskDLQ{
+		dir:         path,
+		maxBytes:    maxBytes,
+		maxAttempts: maxAttempts,
+		put:         put,
+		stopCh:      make(chan struct{}),
+	}, nil
+}
+
+// parseDLQOpts reads ?dlqPath= and ?dlqMaxBytes= from the sink URI query.
+// dlqPath is empty (DLQ disabled) unless explicitly configured.
+func parseDLQOpts(opts url.Values) (path string, maxBytes int64, maxAttempts int) {
+	maxBytes = defaultDLQMaxBytes
+	maxAttempts = defaultDLQMaxAttempts
+
+	if v, ok := firstOpt(opts, "dlqPath"); ok {
+		path = v
+	}
+	if v, ok := firstOpt(opts, "dlqMaxBytes"); ok {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil && parsed > 0 {
+			maxBytes = parsed
+		} else {
+			klog.Warningf("invalid dlqMaxBytes %q for eventbridge sink, using default %v", v, defaultDLQMaxBytes)
+		}
+	}
+	if v, ok := firstOpt(opts, "dlqMaxAttempts"); ok {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			maxAttempts = parsed
+		} else {
+			klog.Warningf("invalid dlqMaxAttempts %q for eventbridge sink, using default %v", v, defaultDLQMaxAttempts)
+		}
+	}
+
+	return path, maxBytes, maxAttempts
+}
+
+// enqueue persists a failed batch to disk and evicts the oldest entries, if
+// any, to keep the queue under maxBytes.
+func (q *diskDLQ) enqueue(events []*eventbridge.CloudEvent) error {
+	entry := dlqEntry{
+		Attempts:    0,
+		NextRetryAt: time.Now().Add(dlqBaseBackoff),
+		Events:      events,
+	}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(q.dir, time.Now().UTC().Format("20060102T150405.000000000Z")+"-"+uuid.New().String()+".json")
+	if err := ioutil.WriteFile(name, raw, 0644); err != nil {
+		return err
+	}
+
+	q.enforceBound()
+	return nil
+}
+
+// dlqFile is one on-disk entry, in either the live queue or the poison
+// subdirectory, with the full path needed to remove it.
+type dlqFile struct {
+	path string
+	size int64
+}
+
+// enforceBound removes the oldest entries, poison first then live queue,
+// until the whole dlq directory (queue plus poison) is under maxBytes or only
+// one entry is left; that last entry is kept even if it alone exceeds
+// maxBytes, so enqueue never deletes the batch it has just written. Both
+// subdirectories are accounted together because poison entries are never
+// retried but still occupy disk, so leaving them out would let a sustained
+// EventBridge outage grow the DLQ without bound even though maxBytes is
+// configured to prevent exactly that.
+func (q *diskDLQ) enforceBound() {
+	queued, err := q.dlqFiles(q.dir)
+	if err != nil {
+		klog.Errorf("failed to list eventbridge dlq entries in %s, because of %v", q.dir, err)
+		return
+	}
+	poisoned, err := q.dlqFiles(filepath.Join(q.dir, dlqPoisonSubdir))
+	if err != nil {
+		klog.Errorf("failed to list eventbridge dlq poison entries in %s, because of %v", q.dir, err)
+		return
+	}
+
+	var total int64
+	for _, f := range queued {
+		total += f.size
+	}
+	for _, f := range poisoned {
+		total += f.size
+	}
+
+	// Evict poison entries first: they have already exceeded maxAttempts
+	// and will never be retried, so they're the cheapest bytes to give up
+	// to stay under bound. Only dip into the live queue once poison is
+	// exhausted and the bound is still not met.
+	remaining := len(poisoned) + len(queued)
+	for _, queue := range [][]dlqFile{poisoned, queued} {
+		for total > q.maxBytes && len(queue) > 0 && remaining > 1 {
+			oldest := queue[0]
+			queue = queue[1:]
+			total -= oldest.size
+			remaining--
+			if err := os.Remove(oldest.path); err != nil {
+				klog.Errorf("failed to evict oldest eventbridge dlq entry %s, because of %v", oldest.path, err)
+			}
+		}
+	}
+}
+
+// dlqFiles lists the ".json" entries directly inside dir, oldest-first by
+// filename (entries are named with a sortable UTC timestamp prefix).
+func (q *diskDLQ) dlqFiles(dir string) ([]dlqFile, error) {
+	all, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]dlqFile, 0, len(all))
+	for _, e := range all {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		var size int64
+		if info, err := e.Info(); err == nil {
+			size = info.Size()
+		}
+		files = append(files, dlqFile{path: filepath.Join(dir, e.Name()), size: size})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files, nil
+}
+
+// entries lists the queued (non-poisoned) dlq files oldest-first.
+func (q *diskDLQ) entries() ([]os.DirEntry, error) {
+	all, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]os.DirEntry, 0, len(all))
+	for _, e := range all {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		files = append(files, e)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+	return files, nil
+}
+
+// Start launches the background goroutine that periodically re-drains the
+// queue. Each call launches its own goroutine.
+func (q *diskDLQ) Start() {
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(dlqDrainInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-q.stopCh:
+				return
+			case <-ticker.C:
+				q.drainOnce()
+			}
+		}
+	}()
+}
+
+// Stop shuts down the background drain goroutine and waits for it to exit.
+func (q *diskDLQ) Stop() {
+	close(q.stopCh)
+	q.wg.Wait()
+}
+
+// drainOnce attempts to redeliver every entry whose backoff has elapsed.
+// Entries that keep failing past maxAttempts are moved to the poison
+// subdirectory instead of being retried forever.
+func (q *diskDLQ) drainOnce() {
+	files, err := q.entries()
+	if err != nil {
+		klog.Errorf("failed to list eventbridge dlq entries in %s, because of %v", q.dir, err)
+		return
+	}
+
+	for _, f := range files {
+		path := filepath.Join(q.dir, f.Name())
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			klog.Errorf("failed to read eventbridge dlq entry %s, because of %v", path, err)
+			continue
+		}
+
+		var entry dlqEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			klog.Errorf("failed to parse eventbridge dlq entry %s, because of %v", path, err)
+			continue
+		}
+
+		if time.Now().Before(entry.NextRetryAt) {
+			continue
+		}
+
+		if err := q.put(entry.Events); err != nil {
+			entry.Attempts++
+			if entry.Attempts >= q.maxAttempts {
+				q.poison(path, f.Name(), entry)
+				continue
+			}
+			entry.NextRetryAt = time.Now().Add(backoffFor(entry.Attempts))
+			if raw, err := json.Marshal(entry); err == nil {
+				_ = ioutil.WriteFile(path, raw, 0644)
+			}
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			klog.Errorf("failed to remove delivered eventbridge dlq entry %s, because of %v", path, err)
+		}
+	}
+}
+
+func (q *diskDLQ) poison(path, name string, entry dlqEntry) {
+	klog.Errorf("eventbridge dlq entry %s exceeded %d attempts, moving %d events to poison", name, q.maxAttempts, len(entry.Events))
+	eventsDroppedTotal.Add(float64(len(entry.Events)))
+	if err := os.Rename(path, filepath.Join(q.dir, dlqPoisonSubdir, name)); err != nil {
+		klog.Errorf("failed to move eventbridge dlq entry %s to poison, because of %v", path, err)
+	}
+	// Poisoning grows the poison subdirectory without going through
+	// enqueue, so it needs its own bound check to keep the whole DLQ tree
+	// under maxBytes during a sustained outage.
+	q.enforceBound()
+}
+
+// maxBackoffShift is the largest attempts value it's safe to left-shift
+// dlqBaseBackoff by: dlqBaseBackoff << 7 already exceeds dlqMaxBackoff, and
+// clamping here (rather than only rejecting a negative/overflowed result
+// afterwards) keeps the shift itself from ever wrapping a user-configured
+// dlqMaxAttempts in the tens.
+const maxBackoffShift = 7
+
+func backoffFor(attempts int) time.Duration {
+	if attempts > maxBackoffShift {
+		attempts = maxBackoffShift
+	}
+	backoff := dlqBaseBackoff << uint(attempts)
+	if backoff > dlqMaxBackoff || backoff <= 0 {
+		return dlqMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,235 @@
+package eventbridge
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alibabacloud-go/eventbridge-sdk/eventbridge"
+)
+
+func newTestDLQ(t *testing.T, maxBytes int64, maxAttempts int, put putEventsImpl) *diskDLQ {
+	t.Helper()
+	q, err := newDiskDLQ(t.TempDir(), maxBytes, maxAttempts, put)
+	if err != nil {
+		t.Fatalf("newDiskDLQ: %v", err)
+	}
+	return q
+}
+
+func testEvents() []*eventbridge.CloudEvent {
+	return []*eventbridge.CloudEvent{new(eventbridge.CloudEvent).SetId("event-1")}
+}
+
+func TestDiskDLQEnqueueAndDrainOnceDelivers(t *testing.T) {
+	var delivered [][]*eventbridge.CloudEvent
+	q := newTestDLQ(t, defaultDLQMaxBytes, defaultDLQMaxAttempts, func(events []*eventbridge.CloudEvent) error {
+		delivered = append(delivered, events)
+		return nil
+	})
+
+	if err := q.enqueue(testEvents()); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	files, err := q.entries()
+	if err != nil {
+		t.Fatalf("entries: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("entries before drain = %d, want 1", len(files))
+	}
+	// enqueue schedules the first retry dlqBaseBackoff in the future; force
+	// it due now so drainOnce doesn't skip it.
+	backdateEntry(t, q, files[0].Name(), 0, time.Now().Add(-time.Second))
+
+	q.drainOnce()
+
+	if len(delivered) != 1 {
+		t.Fatalf("drainOnce delivered %d batches, want 1", len(delivered))
+	}
+	files, err = q.entries()
+	if err != nil {
+		t.Fatalf("entries: %v", err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("entries after successful drain = %d, want 0 (delivered entry should be removed)", len(files))
+	}
+}
+
+func TestDiskDLQDrainOnceSkipsEntriesNotYetDue(t *testing.T) {
+	var calls int
+	q := newTestDLQ(t, defaultDLQMaxBytes, defaultDLQMaxAttempts, func(events []*eventbridge.CloudEvent) error {
+		calls++
+		return nil
+	})
+
+	if err := q.enqueue(testEvents()); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	q.drainOnce()
+
+	if calls != 0 {
+		t.Fatalf("drainOnce called put %d times, want 0 before the entry's backoff elapses", calls)
+	}
+}
+
+func TestDiskDLQDrainOnceReschedulesOnFailure(t *testing.T) {
+	q := newTestDLQ(t, defaultDLQMaxBytes, defaultDLQMaxAttempts, func(events []*eventbridge.CloudEvent) error {
+		return errors.New("put failed")
+	})
+
+	if err := q.enqueue(testEvents()); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	files, _ := q.entries()
+	backdateEntry(t, q, files[0].Name(), 0, time.Now().Add(-time.Second))
+
+	q.drainOnce()
+
+	files, err := q.entries()
+	if err != nil {
+		t.Fatalf("entries: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("entries after failed drain = %d, want 1 (entry kept for retry)", len(files))
+	}
+
+	entry := readEntry(t, q, files[0].Name())
+	if entry.Attempts != 1 {
+		t.Fatalf("Attempts = %d, want 1", entry.Attempts)
+	}
+	if !entry.NextRetryAt.After(time.Now()) {
+		t.Fatal("NextRetryAt should be pushed into the future after a failed attempt")
+	}
+}
+
+func TestDiskDLQDrainOncePoisonsAfterMaxAttempts(t *testing.T) {
+	const maxAttempts = 2
+	q := newTestDLQ(t, defaultDLQMaxBytes, maxAttempts, func(events []*eventbridge.CloudEvent) error {
+		return errors.New("put failed")
+	})
+
+	if err := q.enqueue(testEvents()); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	files, _ := q.entries()
+	name := files[0].Name()
+
+	// Drive the entry's attempts up to maxAttempts, forcing each retry due.
+	for i := 0; i < maxAttempts; i++ {
+		backdateEntry(t, q, name, i, time.Now().Add(-time.Second))
+		q.drainOnce()
+	}
+
+	files, err := q.entries()
+	if err != nil {
+		t.Fatalf("entries: %v", err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("entries after poisoning = %d, want 0", len(files))
+	}
+	if _, err := os.Stat(filepath.Join(q.dir, dlqPoisonSubdir, name)); err != nil {
+		t.Fatalf("expected poisoned entry at %s: %v", filepath.Join(q.dir, dlqPoisonSubdir, name), err)
+	}
+}
+
+func TestDiskDLQPoisonEntriesCountAgainstMaxBytes(t *testing.T) {
+	const maxAttempts = 1
+	q := newTestDLQ(t, 1, maxAttempts, func(events []*eventbridge.CloudEvent) error {
+		return errors.New("put failed")
+	})
+
+	if err := q.enqueue(testEvents()); err != nil {
+		t.Fatalf("enqueue first: %v", err)
+	}
+	files, _ := q.entries()
+	firstName := files[0].Name()
+	backdateEntry(t, q, firstName, 0, time.Now().Add(-time.Second))
+	q.drainOnce()
+
+	if _, err := os.Stat(filepath.Join(q.dir, dlqPoisonSubdir, firstName)); err != nil {
+		t.Fatalf("expected first entry poisoned: %v", err)
+	}
+
+	if err := q.enqueue(testEvents()); err != nil {
+		t.Fatalf("enqueue second: %v", err)
+	}
+
+	poisoned, err := q.dlqFiles(filepath.Join(q.dir, dlqPoisonSubdir))
+	if err != nil {
+		t.Fatalf("dlqFiles(poison): %v", err)
+	}
+	if len(poisoned) != 0 {
+		t.Fatalf("poison entries after second enqueue = %d, want 0 (poison bytes must count against maxBytes)", len(poisoned))
+	}
+}
+
+func TestBackoffForClampsShiftBeforeOverflow(t *testing.T) {
+	if got := backoffFor(1000); got != dlqMaxBackoff {
+		t.Fatalf("backoffFor(1000) = %v, want dlqMaxBackoff %v (clamped, not overflowed to near-zero)", got, dlqMaxBackoff)
+	}
+}
+
+func TestDiskDLQEnforceBoundEvictsOldestEntries(t *testing.T) {
+	q := newTestDLQ(t, 1, defaultDLQMaxAttempts, func(events []*eventbridge.CloudEvent) error { return nil })
+
+	if err := q.enqueue(testEvents()); err != nil {
+		t.Fatalf("enqueue first: %v", err)
+	}
+	if err := q.enqueue(testEvents()); err != nil {
+		t.Fatalf("enqueue second: %v", err)
+	}
+
+	files, err := q.entries()
+	if err != nil {
+		t.Fatalf("entries: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("entries after enforceBound = %d, want 1 (oldest evicted to stay under maxBytes)", len(files))
+	}
+}
+
+func TestDiskDLQStartStop(t *testing.T) {
+	q := newTestDLQ(t, defaultDLQMaxBytes, defaultDLQMaxAttempts, func(events []*eventbridge.CloudEvent) error { return nil })
+	q.Start()
+	q.Stop()
+}
+
+// backdateEntry rewrites the on-disk dlq entry at name with the given
+// attempts/nextRetryAt, so tests don't have to wait out real backoff windows.
+func backdateEntry(t *testing.T, q *diskDLQ, name string, attempts int, nextRetryAt time.Time) {
+	t.Helper()
+	entry := readEntry(t, q, name)
+	entry.Attempts = attempts
+	entry.NextRetryAt = nextRetryAt
+	writeEntry(t, q, name, entry)
+}
+
+func readEntry(t *testing.T, q *diskDLQ, name string) dlqEntry {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(q.dir, name))
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", name, err)
+	}
+	var entry dlqEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		t.Fatalf("unmarshal entry %s: %v", name, err)
+	}
+	return entry
+}
+
+func writeEntry(t *testing.T, q *diskDLQ, name string, entry dlqEntry) {
+	t.Helper()
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("marshal entry %s: %v", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(q.dir, name), raw, 0644); err != nil {
+		t.Fatalf("WriteFile(%s): %v", name, err)
+	}
+}
@@ -1,38 +1,41 @@
 package eventbridge
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/AliyunContainerService/kube-eventer/core"
+	cloudeventsconv "github.com/AliyunContainerService/kube-eventer/sinks/cloudevents"
 	"github.com/AliyunContainerService/kube-eventer/sinks/utils"
 	"github.com/alibabacloud-go/eventbridge-sdk/eventbridge"
 	ebUtil "github.com/alibabacloud-go/tea-utils/service"
-	"github.com/google/uuid"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/klog"
 	"math"
 	"net/url"
-	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	eventBridgeSinkName          = "EventBridgeSink"
-	defaultBusName               = "default"
-	eventBridgeEndpointSchema    = "%v.eventbridge.%v-vpc.aliyuncs.com"
-	aliyunContainerServiceSource = "acs.cs"
-	eventbridgeMaxBatchSize      = 16
-	defaultEventType             = "cs:k8s:K8s-event-via-npd"
+	eventBridgeSinkName       = "EventBridgeSink"
+	eventBridgeEndpointSchema = "%v.eventbridge.%v-vpc.aliyuncs.com"
+	eventbridgeMaxBatchSize   = 16
 )
 
 type eventBridgeSink struct {
+	// clientMu guards client/akInfo, which are read and refreshed both from
+	// ExportEvents and from the DLQ's own redrive goroutine (see dlq.go).
+	clientMu  sync.Mutex
 	client    *eventbridge.Client
 	akInfo    *utils.AKInfo
 	clusterId string
 	region    string
 	accountId string
+	limiter   *adaptiveRateLimiter
+	router    *router
+	dlq       *diskDLQ
+	enricher  cloudeventsconv.InvolvedObjectEnricher
 }
 
 type putEventsImpl func(events []*eventbridge.CloudEvent) error
@@ -61,6 +64,37 @@ func NewEventBridgeSink(uri *url.URL) (core.EventSink, error) {
 	ebSink.region = region
 	ebSink.accountId = accountId
 
+	qps, burst := parseRateLimitOpts(opts)
+	ebSink.limiter = newAdaptiveRateLimiter(qps, burst)
+
+	enrichInvolvedObject := len(opts["enrichInvolvedObject"]) >= 1 && opts["enrichInvolvedObject"][0] == "true"
+	if enrichInvolvedObject {
+		enricher, err := cloudeventsconv.NewInvolvedObjectEnricher()
+		if err != nil {
+			return nil, err
+		}
+		ebSink.enricher = enricher
+	}
+
+	fallback := destination{
+		busName:   cloudeventsconv.DefaultBusName,
+		eventType: cloudeventsconv.DefaultType,
+		source:    cloudeventsconv.DefaultSource,
+	}
+	ebSink.router, err = newRouter(uri, fallback, enrichInvolvedObject)
+	if err != nil {
+		return nil, err
+	}
+
+	if dlqPath, dlqMaxBytes, dlqMaxAttempts := parseDLQOpts(opts); dlqPath != "" {
+		dlq, err := newDiskDLQ(dlqPath, dlqMaxBytes, dlqMaxAttempts, ebSink.doPutEvents)
+		if err != nil {
+			return nil, err
+		}
+		ebSink.dlq = dlq
+		ebSink.dlq.Start()
+	}
+
 	return ebSink, nil
 }
 
@@ -79,40 +113,93 @@ func (ebSink *eventBridgeSink) ExportEvents(batch *core.EventBatch) {
 }
 
 func (ebSink *eventBridgeSink) Stop() {
-	//no background task, no need to implement
+	if ebSink.dlq != nil {
+		ebSink.dlq.Stop()
+	}
 }
 
-func (ebSink *eventBridgeSink) toCloudEvent(event *v1.Event) (*eventbridge.CloudEvent, error) {
-	resourceName := event.Name
-	kind := event.Kind
-	namespace := event.Namespace
-	subject := ebSink.createEventSubject(v1.ObjectReference{
-		APIVersion: event.APIVersion,
-		Kind:       kind,
-		Name:       resourceName,
-		Namespace:  namespace,
-	})
-
-	dataBytes, err := json.Marshal(event)
+// toCloudEvent converts a v1.Event into the Aliyun SDK's wire representation
+// of a CloudEvent, routed to dest. The event itself is built once by the
+// shared cloudevents.ToCloudEvent converter, so EventBridge and every other
+// CloudEvents transport (HTTP, MQTT, Kafka) emit identical events; dest only
+// overrides the routing-sensitive type/source/bus attributes.
+func (ebSink *eventBridgeSink) toCloudEvent(event *v1.Event, dest destination, involved cloudeventsconv.InvolvedObject) (*eventbridge.CloudEvent, error) {
+	ce, err := cloudeventsconv.ToCloudEvent(ebSink.eventContext(), event, involved)
 	if err != nil {
 		return nil, err
 	}
 
-	cloudEvent := new(eventbridge.CloudEvent).
-		SetDatacontenttype("application/json").
-		SetData(dataBytes).
-		SetId(uuid.New().String()).
-		SetSource(aliyunContainerServiceSource).
-		SetTime(time.Now().Format(time.RFC3339)).
-		SetSubject(subject).
-		SetType(defaultEventType).
-		SetExtensions(map[string]interface{}{
-			"aliyuneventbusname": defaultBusName,
-		})
-	return cloudEvent, nil
+	extensions := make(map[string]interface{}, len(ce.Extensions()))
+	for k, v := range ce.Extensions() {
+		extensions[k] = v
+	}
+	extensions["aliyuneventbusname"] = dest.busName
+
+	return new(eventbridge.CloudEvent).
+		SetDatacontenttype(ce.DataContentType()).
+		SetData(ce.Data()).
+		SetId(ce.ID()).
+		SetSource(dest.source).
+		SetTime(ce.Time().Format(time.RFC3339)).
+		SetSubject(ce.Subject()).
+		SetType(dest.eventType).
+		SetExtensions(extensions), nil
 }
 
+// fetchInvolvedObject looks up the involved object's labels/annotations at
+// most once per event, so the result can be reused for both routing
+// decisions (label selector rules) and the CloudEvent's enrichment
+// extensions. Returns the zero value when enrichment isn't configured.
+func (ebSink *eventBridgeSink) fetchInvolvedObject(event *v1.Event) cloudeventsconv.InvolvedObject {
+	if ebSink.enricher == nil {
+		return cloudeventsconv.InvolvedObject{}
+	}
+
+	labels, annotations, err := ebSink.enricher.Enrich(event.InvolvedObject)
+	if err != nil {
+		klog.Errorf("failed to enrich involved object for event %v, beacause of %v", event, err)
+		return cloudeventsconv.InvolvedObject{}
+	}
+
+	return cloudeventsconv.InvolvedObject{Labels: labels, Annotations: annotations}
+}
+
+func (ebSink *eventBridgeSink) eventContext() cloudeventsconv.EventContext {
+	return cloudeventsconv.EventContext{
+		Region:    ebSink.region,
+		AccountId: ebSink.accountId,
+		ClusterId: ebSink.clusterId,
+	}
+}
+
+// putEvents delivers events to EventBridge and, if that fails, spills them to
+// the disk-backed DLQ (if configured) instead of losing them. It is what
+// exportEventsInBatch calls for freshly-produced batches.
 func (ebSink *eventBridgeSink) putEvents(events []*eventbridge.CloudEvent) error {
+	err := ebSink.doPutEvents(events)
+	if err == nil || ebSink.dlq == nil {
+		return err
+	}
+
+	if dlqErr := ebSink.dlq.enqueue(events); dlqErr != nil {
+		klog.Errorf("failed to persist %d events to eventbridge dlq, beacause of %v", len(events), dlqErr)
+		return err
+	}
+	klog.Warningf("queued %d events to disk-backed dead-letter queue after put failure: %v", len(events), err)
+	return nil
+}
+
+// doPutEvents is the raw EventBridge PutEvents call, shared by putEvents and
+// by the DLQ's own redrive loop (which must not re-enqueue on failure).
+func (ebSink *eventBridgeSink) doPutEvents(events []*eventbridge.CloudEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := ebSink.limiter.WaitN(context.Background(), len(events)); err != nil {
+		return err
+	}
+
 	ebClient, err := ebSink.getClient()
 	if err != nil {
 		return err
@@ -120,36 +207,69 @@ func (ebSink *eventBridgeSink) putEvents(events []*eventbridge.CloudEvent) error
 	runtime := &ebUtil.RuntimeOptions{}
 	runtime.SetAutoretry(true)
 	_, err = ebClient.PutEventsWithOptions(events, runtime)
+
+	if isThrottlingError(err) {
+		eventsThrottledTotal.Add(float64(len(events)))
+		ebSink.limiter.Throttled()
+	}
+
 	return err
 }
 
+// exportEventsInBatch resolves each event to its destination bus, groups
+// events by bus so that a single kube-eventer deployment can fan out to
+// multiple downstream EventBridge buses, and issues one PutEventsWithOptions
+// per bus per eventbridgeMaxBatchSize-sized chunk.
 func (ebSink *eventBridgeSink) exportEventsInBatch(batch *core.EventBatch, putEvents putEventsImpl) {
-	batchSize := int(math.Ceil(float64(len(batch.Events)) / eventbridgeMaxBatchSize))
-	for i := 0; i < batchSize; i++ {
-		events := make([]*eventbridge.CloudEvent, 0, eventbridgeMaxBatchSize)
-		for j := i * eventbridgeMaxBatchSize; j < (i+1)*eventbridgeMaxBatchSize && j < len(batch.Events); j++ {
-			cloudEvent, err := ebSink.toCloudEvent(batch.Events[j])
-			if err != nil {
-				klog.Errorf("failed to convert event %v to cloudevents, beacause of %v", batch.Events[j], err)
-				continue
-			}
-			events = append(events, cloudEvent)
+	eventsByBus := make(map[string][]*eventbridge.CloudEvent)
+
+	for _, event := range batch.Events {
+		involved := ebSink.fetchInvolvedObject(event)
+
+		dest, ok := ebSink.router.resolve(event, involved.Labels)
+		if !ok {
+			eventsDroppedTotal.Inc()
+			continue
 		}
-		err := putEvents(events)
 
+		cloudEvent, err := ebSink.toCloudEvent(event, dest, involved)
 		if err != nil {
-			klog.Errorf("failed to put events to eventbridge, beacause of %v", err)
+			klog.Errorf("failed to convert event %v to cloudevents, beacause of %v", event, err)
+			eventsDroppedTotal.Inc()
+			continue
+		}
+		eventsByBus[dest.busName] = append(eventsByBus[dest.busName], cloudEvent)
+	}
+
+	for busName, events := range eventsByBus {
+		batchSize := int(math.Ceil(float64(len(events)) / eventbridgeMaxBatchSize))
+		for i := 0; i < batchSize; i++ {
+			end := (i + 1) * eventbridgeMaxBatchSize
+			if end > len(events) {
+				end = len(events)
+			}
+			if err := putEvents(events[i*eventbridgeMaxBatchSize : end]); err != nil {
+				klog.Errorf("failed to put events to eventbridge bus %s, beacause of %v", busName, err)
+			}
 		}
 	}
 }
 
+// getClient returns a valid EventBridge client, refreshing it first if the
+// current AK has expired or no client has been created yet. It serializes
+// access to client/akInfo under clientMu since ExportEvents and the DLQ
+// redrive loop can call it concurrently.
 func (ebSink *eventBridgeSink) getClient() (*eventbridge.Client, error) {
+	ebSink.clientMu.Lock()
+	defer ebSink.clientMu.Unlock()
+
 	if ebSink.client != nil && ebSink.isAkValid() {
 		return ebSink.client, nil
 	}
 	return ebSink.newClient()
 }
 
+// newClient must be called with clientMu held.
 func (ebSink *eventBridgeSink) newClient() (*eventbridge.Client, error) {
 	endpoint := fmt.Sprintf(eventBridgeEndpointSchema, ebSink.accountId, ebSink.region)
 
@@ -175,6 +295,7 @@ func (ebSink *eventBridgeSink) newClient() (*eventbridge.Client, error) {
 	return client, nil
 }
 
+// isAkValid must be called with clientMu held.
 func (ebSink *eventBridgeSink) isAkValid() bool {
 	layout := "2006-01-02T15:04:05Z"
 	t, err := time.Parse(layout, ebSink.akInfo.Expiration)
@@ -196,18 +317,3 @@ func (ebSink *eventBridgeSink) isAkValid() bool {
 
 	return true
 }
-
-// Creates a cloudevents subject of the form found in object metadata selfLinks
-// like: acs:cs:${Region}:${Account}:${ClusterId}/${selfLink}
-func (ebSink *eventBridgeSink) createEventSubject(o v1.ObjectReference) string {
-	gvr, _ := meta.UnsafeGuessKindToResource(o.GroupVersionKind())
-	versionNameHack := o.APIVersion
-
-	// Core API types don't have a separate package name and only have a version string (e.g. /apis/v1/namespaces/default/pods/myPod)
-	// To avoid weird looking strings like "v1/versionUnknown" we'll sniff for a "." in the version
-	if strings.Contains(versionNameHack, ".") && !strings.Contains(versionNameHack, "/") {
-		versionNameHack = versionNameHack + "/versionUnknown"
-	}
-	return fmt.Sprintf("acs:cs:%s:%s:%s/apis/%s/namespaces/%s/%s/%s", ebSink.region, ebSink.accountId,
-		ebSink.clusterId, versionNameHack, o.Namespace, gvr.Resource, o.Name)
-}
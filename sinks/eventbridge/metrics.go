@@ -0,0 +1,39 @@
+package eventbridge
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// eventsThrottledTotal counts CloudEvents that hit an EventBridge
+// PutEvents throttling response, whether or not they were ultimately
+// delivered after backoff. Operators use it to size ?qps=/?burst=.
+var eventsThrottledTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kube_eventer_eventbridge_events_throttled_total",
+	Help: "Number of events that hit an EventBridge PutEvents throttling response.",
+})
+
+// eventsDroppedTotal counts events dropped by the sink without ever being
+// delivered to EventBridge, e.g. because conversion failed.
+var eventsDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kube_eventer_eventbridge_events_dropped_total",
+	Help: "Number of events dropped by the EventBridge sink without being delivered.",
+})
+
+func init() {
+	prometheus.MustRegister(eventsThrottledTotal, eventsDroppedTotal)
+}
+
+// isThrottlingError reports whether err is an EventBridge/OpenAPI
+// throttling response (HTTP 429 or the "Throttling*" error codes the
+// Aliyun SDK surfaces for QPS-quota rejections).
+func isThrottlingError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Throttling") ||
+		strings.Contains(msg, "429") ||
+		strings.Contains(msg, "TooManyRequests")
+}
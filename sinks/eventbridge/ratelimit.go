@@ -0,0 +1,116 @@
+package eventbridge
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog"
+)
+
+const (
+	// defaultQPS/defaultBurst are used when the sink URI doesn't specify
+	// ?qps=/?burst=, matching the previous un-throttled behaviour closely
+	// enough to not surprise existing users at normal event volumes.
+	defaultQPS   = 20
+	defaultBurst = 40
+
+	// throttleBackoffFactor is how much the effective rate is cut on a 429,
+	// and throttleCooldown is how long it stays cut before being restored.
+	throttleBackoffFactor = 2
+	throttleCooldown      = time.Minute
+)
+
+// adaptiveRateLimiter wraps a token-bucket rate.Limiter that halves its rate
+// on EventBridge throttling responses and restores the configured rate after
+// a cooldown window with no further throttling.
+type adaptiveRateLimiter struct {
+	mu sync.Mutex
+
+	configured rate.Limit
+	burst      int
+
+	limiter   *rate.Limiter
+	restoreAt time.Time
+}
+
+func newAdaptiveRateLimiter(qps, burst int) *adaptiveRateLimiter {
+	// WaitN errors outright whenever n exceeds the limiter's burst, and
+	// putEvents calls WaitN with up to eventbridgeMaxBatchSize events per
+	// batch, so burst must never be configured below that or every batch
+	// would fail permanently instead of just being rate-limited.
+	if burst < eventbridgeMaxBatchSize {
+		burst = eventbridgeMaxBatchSize
+	}
+
+	limit := rate.Limit(qps)
+	return &adaptiveRateLimiter{
+		configured: limit,
+		burst:      burst,
+		limiter:    rate.NewLimiter(limit, burst),
+	}
+}
+
+// WaitN blocks until n events may be sent without exceeding the current
+// (possibly backed-off) rate.
+func (l *adaptiveRateLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	if !l.restoreAt.IsZero() && time.Now().After(l.restoreAt) {
+		l.limiter.SetLimit(l.configured)
+		l.restoreAt = time.Time{}
+	}
+	limiter := l.limiter
+	l.mu.Unlock()
+
+	return limiter.WaitN(ctx, n)
+}
+
+// Throttled halves the effective rate and starts (or extends) the cooldown
+// window after which the configured rate is restored.
+func (l *adaptiveRateLimiter) Throttled() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := l.limiter.Limit()
+	reduced := current / throttleBackoffFactor
+	if reduced < 1 {
+		reduced = 1
+	}
+	l.limiter.SetLimit(reduced)
+	l.restoreAt = time.Now().Add(throttleCooldown)
+
+	klog.Warningf("eventbridge sink throttled, reduced rate to %v events/s for %v", reduced, throttleCooldown)
+}
+
+// parseRateLimitOpts reads ?qps= and ?burst= from the sink URI query,
+// falling back to defaultQPS/defaultBurst when absent or invalid.
+func parseRateLimitOpts(opts map[string][]string) (qps, burst int) {
+	qps, burst = defaultQPS, defaultBurst
+
+	if v, ok := firstOpt(opts, "qps"); ok {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			qps = parsed
+		} else {
+			klog.Warningf("invalid qps %q for eventbridge sink, using default %v", v, defaultQPS)
+		}
+	}
+
+	if v, ok := firstOpt(opts, "burst"); ok {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			burst = parsed
+		} else {
+			klog.Warningf("invalid burst %q for eventbridge sink, using default %v", v, defaultBurst)
+		}
+	}
+
+	return qps, burst
+}
+
+func firstOpt(opts map[string][]string, key string) (string, bool) {
+	if v, ok := opts[key]; ok && len(v) >= 1 {
+		return v[0], true
+	}
+	return "", false
+}
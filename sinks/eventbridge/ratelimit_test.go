@@ -0,0 +1,95 @@
+package eventbridge
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewAdaptiveRateLimiterClampsBurst(t *testing.T) {
+	l := newAdaptiveRateLimiter(10, eventbridgeMaxBatchSize-1)
+	if l.burst != eventbridgeMaxBatchSize {
+		t.Fatalf("burst = %d, want %d (clamped to eventbridgeMaxBatchSize)", l.burst, eventbridgeMaxBatchSize)
+	}
+
+	l = newAdaptiveRateLimiter(10, eventbridgeMaxBatchSize+5)
+	if l.burst != eventbridgeMaxBatchSize+5 {
+		t.Fatalf("burst = %d, want %d (left untouched)", l.burst, eventbridgeMaxBatchSize+5)
+	}
+}
+
+func TestAdaptiveRateLimiterWaitNAllowsConfiguredBurst(t *testing.T) {
+	l := newAdaptiveRateLimiter(10, eventbridgeMaxBatchSize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.WaitN(ctx, eventbridgeMaxBatchSize); err != nil {
+		t.Fatalf("WaitN for a full batch within burst: %v", err)
+	}
+}
+
+func TestAdaptiveRateLimiterThrottledHalvesRate(t *testing.T) {
+	l := newAdaptiveRateLimiter(100, eventbridgeMaxBatchSize)
+
+	before := l.limiter.Limit()
+	l.Throttled()
+	after := l.limiter.Limit()
+
+	if after != before/throttleBackoffFactor {
+		t.Fatalf("limit after Throttled = %v, want %v", after, before/throttleBackoffFactor)
+	}
+	if l.restoreAt.IsZero() {
+		t.Fatal("Throttled did not set a restoreAt cooldown")
+	}
+}
+
+func TestAdaptiveRateLimiterThrottledFloorsAtOne(t *testing.T) {
+	l := newAdaptiveRateLimiter(1, eventbridgeMaxBatchSize)
+
+	l.Throttled()
+	l.Throttled()
+	l.Throttled()
+	l.Throttled()
+
+	if l.limiter.Limit() < 1 {
+		t.Fatalf("limit = %v, should never drop below 1 event/s", l.limiter.Limit())
+	}
+}
+
+func TestAdaptiveRateLimiterRestoresAfterCooldown(t *testing.T) {
+	l := newAdaptiveRateLimiter(50, eventbridgeMaxBatchSize)
+	l.Throttled()
+	if l.limiter.Limit() == l.configured {
+		t.Fatal("expected rate to be reduced immediately after Throttled")
+	}
+
+	// Pretend the cooldown window has already elapsed.
+	l.restoreAt = time.Now().Add(-time.Second)
+
+	if err := l.WaitN(context.Background(), 1); err != nil {
+		t.Fatalf("WaitN: %v", err)
+	}
+	if l.limiter.Limit() != l.configured {
+		t.Fatalf("limit after cooldown = %v, want restored configured rate %v", l.limiter.Limit(), l.configured)
+	}
+	if !l.restoreAt.IsZero() {
+		t.Fatal("restoreAt should be cleared once the configured rate is restored")
+	}
+}
+
+func TestParseRateLimitOptsDefaultsAndOverrides(t *testing.T) {
+	qps, burst := parseRateLimitOpts(map[string][]string{})
+	if qps != defaultQPS || burst != defaultBurst {
+		t.Fatalf("defaults = (%d, %d), want (%d, %d)", qps, burst, defaultQPS, defaultBurst)
+	}
+
+	qps, burst = parseRateLimitOpts(map[string][]string{"qps": {"5"}, "burst": {"15"}})
+	if qps != 5 || burst != 15 {
+		t.Fatalf("overrides = (%d, %d), want (5, 15)", qps, burst)
+	}
+
+	qps, burst = parseRateLimitOpts(map[string][]string{"qps": {"not-a-number"}, "burst": {"-1"}})
+	if qps != defaultQPS || burst != defaultBurst {
+		t.Fatalf("invalid overrides = (%d, %d), want defaults (%d, %d)", qps, burst, defaultQPS, defaultBurst)
+	}
+}
@@ -0,0 +1,167 @@
+package eventbridge
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+)
+
+// destination is the resolved routing decision for a single event: which
+// EventBridge bus it should be delivered to, and which CloudEvent type/source
+// it should be tagged with on the way there.
+type destination struct {
+	busName   string
+	eventType string
+	source    string
+}
+
+// routeRule matches events on a combination of namespace, reason, event type,
+// involved-object kind and involved-object label selector. Any empty field is
+// treated as "match anything" for that dimension.
+type routeRule struct {
+	Namespace     string `json:"namespace,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+	Type          string `json:"type,omitempty"`
+	Kind          string `json:"kind,omitempty"`
+	LabelSelector string `json:"labelSelector,omitempty"`
+
+	BusName   string `json:"busName"`
+	EventType string `json:"eventType,omitempty"`
+	Source    string `json:"source,omitempty"`
+
+	selector labels.Selector
+}
+
+// routerConfig is the shape of the YAML file pointed to by ?routerConfig=.
+type routerConfig struct {
+	// DefaultAction is applied when no rule matches: "default" (the zero
+	// value) routes to the router's fallback destination, "drop" discards
+	// the event entirely.
+	DefaultAction string      `json:"defaultAction,omitempty"`
+	Rules         []routeRule `json:"rules,omitempty"`
+}
+
+const (
+	defaultActionRouteToDefault = "default"
+	defaultActionDrop           = "drop"
+)
+
+// router resolves each event to a destination bus/type/source, so a single
+// kube-eventer deployment can fan events for different namespaces/reasons out
+// to different downstream EventBridge buses.
+type router struct {
+	defaultAction string
+	rules         []routeRule
+	fallback      destination
+}
+
+// newRouter builds a router from the sink URI. Rules are loaded from the YAML
+// file at ?routerConfig=/path/to/rules.yaml if present; otherwise every event
+// falls back to the single default bus/type/source (today's behaviour).
+// enrichInvolvedObject must match whether the sink's involved-object enricher
+// is actually enabled (?enrichInvolvedObject=true): a rule's labelSelector is
+// matched against the involved object's live labels, which are only fetched
+// when enrichment is on, so a labelSelector rule without it would silently
+// never match anything at runtime instead of failing loudly here.
+func newRouter(uri *url.URL, fallback destination, enrichInvolvedObject bool) (*router, error) {
+	r := &router{
+		defaultAction: defaultActionRouteToDefault,
+		fallback:      fallback,
+	}
+
+	opts := uri.Query()
+	if action, ok := firstOpt(opts, "defaultAction"); ok {
+		r.defaultAction = action
+	}
+
+	path, ok := firstOpt(opts, "routerConfig")
+	if !ok {
+		return r, nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg routerConfig
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.DefaultAction != "" {
+		r.defaultAction = cfg.DefaultAction
+	}
+
+	for i := range cfg.Rules {
+		rule := cfg.Rules[i]
+		if rule.LabelSelector != "" {
+			if !enrichInvolvedObject {
+				return nil, fmt.Errorf("routing rule %d uses labelSelector %q but enrichInvolvedObject is not enabled; involved-object labels are only fetched when ?enrichInvolvedObject=true, so this rule would never match", i, rule.LabelSelector)
+			}
+			selector, err := labels.Parse(rule.LabelSelector)
+			if err != nil {
+				return nil, err
+			}
+			rule.selector = selector
+		}
+		r.rules = append(r.rules, rule)
+	}
+
+	klog.Infof("eventbridge sink loaded %d routing rules from %s", len(r.rules), path)
+	return r, nil
+}
+
+// resolve matches event (and, if known, its involved object's labels)
+// against the configured rules in order and returns the destination the
+// event should be delivered to, plus false if it should be dropped.
+func (r *router) resolve(event *v1.Event, involvedLabels labels.Set) (destination, bool) {
+	for _, rule := range r.rules {
+		if !rule.matches(event, involvedLabels) {
+			continue
+		}
+		dest := destination{
+			busName:   rule.BusName,
+			eventType: rule.EventType,
+			source:    rule.Source,
+		}
+		if dest.busName == "" {
+			dest.busName = r.fallback.busName
+		}
+		if dest.eventType == "" {
+			dest.eventType = r.fallback.eventType
+		}
+		if dest.source == "" {
+			dest.source = r.fallback.source
+		}
+		return dest, true
+	}
+
+	if r.defaultAction == defaultActionDrop {
+		return destination{}, false
+	}
+	return r.fallback, true
+}
+
+func (rule routeRule) matches(event *v1.Event, involvedLabels labels.Set) bool {
+	if rule.Namespace != "" && rule.Namespace != event.Namespace {
+		return false
+	}
+	if rule.Reason != "" && rule.Reason != event.Reason {
+		return false
+	}
+	if rule.Type != "" && rule.Type != event.Type {
+		return false
+	}
+	if rule.Kind != "" && rule.Kind != event.InvolvedObject.Kind {
+		return false
+	}
+	if rule.selector != nil && !rule.selector.Matches(involvedLabels) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package eventbridge
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var testFallback = destination{
+	busName:   "default-bus",
+	eventType: "default.type",
+	source:    "default-source",
+}
+
+func mustURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse(%q): %v", raw, err)
+	}
+	return u
+}
+
+func TestRouterResolveFallsBackWithNoRules(t *testing.T) {
+	r, err := newRouter(mustURL(t, "eventbridge://x"), testFallback, false)
+	if err != nil {
+		t.Fatalf("newRouter: %v", err)
+	}
+
+	event := &v1.Event{}
+	dest, ok := r.resolve(event, nil)
+	if !ok || dest != testFallback {
+		t.Fatalf("resolve() = (%v, %v), want (%v, true)", dest, ok, testFallback)
+	}
+}
+
+func TestRouterResolveDropsWhenDefaultActionIsDrop(t *testing.T) {
+	r := &router{defaultAction: defaultActionDrop, fallback: testFallback}
+
+	if _, ok := r.resolve(&v1.Event{}, nil); ok {
+		t.Fatal("resolve() should drop the event when defaultAction is drop and no rule matches")
+	}
+}
+
+func TestRouterResolveMatchesNamespaceAndReason(t *testing.T) {
+	r := &router{
+		fallback: testFallback,
+		rules: []routeRule{
+			{Namespace: "kube-system", Reason: "Failed", BusName: "alerts-bus"},
+		},
+	}
+
+	matching := &v1.Event{Reason: "Failed"}
+	matching.Namespace = "kube-system"
+	dest, ok := r.resolve(matching, nil)
+	if !ok || dest.busName != "alerts-bus" {
+		t.Fatalf("resolve(matching) = (%v, %v), want busName alerts-bus", dest, ok)
+	}
+
+	other := &v1.Event{Reason: "Failed"}
+	other.Namespace = "default"
+	dest, ok = r.resolve(other, nil)
+	if !ok || dest != testFallback {
+		t.Fatalf("resolve(non-matching namespace) = (%v, %v), want fallback", dest, ok)
+	}
+}
+
+func TestRouterResolveFillsUnsetFieldsFromFallback(t *testing.T) {
+	r := &router{
+		fallback: testFallback,
+		rules: []routeRule{
+			{Reason: "Failed", BusName: "alerts-bus"},
+		},
+	}
+
+	dest, ok := r.resolve(&v1.Event{Reason: "Failed"}, nil)
+	if !ok {
+		t.Fatal("resolve() should match")
+	}
+	if dest.busName != "alerts-bus" {
+		t.Errorf("busName = %q, want %q", dest.busName, "alerts-bus")
+	}
+	if dest.eventType != testFallback.eventType {
+		t.Errorf("eventType = %q, want fallback %q", dest.eventType, testFallback.eventType)
+	}
+	if dest.source != testFallback.source {
+		t.Errorf("source = %q, want fallback %q", dest.source, testFallback.source)
+	}
+}
+
+func TestRouterResolveMatchesLabelSelector(t *testing.T) {
+	selector, err := labels.Parse("app=payments")
+	if err != nil {
+		t.Fatalf("labels.Parse: %v", err)
+	}
+	r := &router{
+		fallback: testFallback,
+		rules: []routeRule{
+			{BusName: "payments-bus", selector: selector},
+		},
+	}
+
+	dest, ok := r.resolve(&v1.Event{}, labels.Set{"app": "payments"})
+	if !ok || dest.busName != "payments-bus" {
+		t.Fatalf("resolve(matching labels) = (%v, %v), want busName payments-bus", dest, ok)
+	}
+
+	dest, ok = r.resolve(&v1.Event{}, labels.Set{"app": "other"})
+	if !ok || dest != testFallback {
+		t.Fatalf("resolve(non-matching labels) = (%v, %v), want fallback", dest, ok)
+	}
+}
+
+func TestNewRouterRejectsLabelSelectorRuleWithoutEnrichment(t *testing.T) {
+	path := writeRouterConfig(t, `
+rules:
+  - labelSelector: "app=payments"
+    busName: payments-bus
+`)
+
+	if _, err := newRouter(mustURL(t, "eventbridge://x?routerConfig="+path), testFallback, false); err == nil {
+		t.Fatal("newRouter should reject a labelSelector rule when enrichInvolvedObject is disabled, since it would never match at runtime")
+	}
+}
+
+func TestNewRouterAcceptsLabelSelectorRuleWithEnrichment(t *testing.T) {
+	path := writeRouterConfig(t, `
+rules:
+  - labelSelector: "app=payments"
+    busName: payments-bus
+`)
+
+	r, err := newRouter(mustURL(t, "eventbridge://x?routerConfig="+path), testFallback, true)
+	if err != nil {
+		t.Fatalf("newRouter: %v", err)
+	}
+
+	dest, ok := r.resolve(&v1.Event{}, labels.Set{"app": "payments"})
+	if !ok || dest.busName != "payments-bus" {
+		t.Fatalf("resolve() = (%v, %v), want busName payments-bus", dest, ok)
+	}
+}
+
+func writeRouterConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestRouterResolveFirstMatchWins(t *testing.T) {
+	r := &router{
+		fallback: testFallback,
+		rules: []routeRule{
+			{Reason: "Failed", BusName: "first-bus"},
+			{Reason: "Failed", BusName: "second-bus"},
+		},
+	}
+
+	dest, ok := r.resolve(&v1.Event{Reason: "Failed"}, nil)
+	if !ok || dest.busName != "first-bus" {
+		t.Fatalf("resolve() = (%v, %v), want first matching rule's busName", dest, ok)
+	}
+}